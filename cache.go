@@ -1,66 +1,117 @@
-package rediscache 
+package rediscache
 
 import (
-	"encoding/json"
+	"context"
 	"time"
 
-	"github.com/go-redis/redis"
+	"github.com/redis/go-redis/v9"
 )
 
 var ErrCacheMiss = redis.Nil
 
 type Cache interface {
-	Get(id string, res interface{}) error
-	Set(id string, res interface{}) error
-	SetRaw(id string, data []byte) error
-	Delete(id string) error
-	Expire(id string, at time.Time) error
+	Get(ctx context.Context, id string, res interface{}) error
+	GetRaw(ctx context.Context, id string) ([]byte, error)
+	Set(ctx context.Context, id string, res interface{}) error
+	SetWithTTL(ctx context.Context, id string, res interface{}, ttl time.Duration) error
+	SetRaw(ctx context.Context, id string, data []byte) error
+	SetRawWithTTL(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	Delete(ctx context.Context, id string) error
+	Expire(ctx context.Context, id string, at time.Time) error
+
+	// GetMulti fetches ids (in a single MGET round trip on a Cache from
+	// New). For each id found, out[id] is set to factory(id) with the
+	// cached value decoded into it; ids that miss are simply absent from
+	// out.
+	GetMulti(ctx context.Context, ids []string, out map[string]interface{}, factory func(id string) interface{}) error
+	// SetMulti writes every id/value pair in items (on a Cache from New,
+	// pipelined in batches of at most MaxPipelineSize commands).
+	SetMulti(ctx context.Context, items map[string]interface{}) error
+	// DeleteMulti deletes every id in ids (in a single DEL round trip on
+	// a Cache from New).
+	DeleteMulti(ctx context.Context, ids []string) error
+
 	Begin(max time.Duration) Cache
-	End() error
+	End(ctx context.Context) error
+}
+
+// New constructs a Cache backed by client, prefixing every key with
+// prefix. Values passed to Get/Set are (de)serialized with JSONCodec
+// unless overridden via WithCodec, and expire after DefaultTTL (one hour
+// by default) unless a *WithTTL variant is used.
+func New(client redis.UniversalClient, prefix string, opts ...Option) Cache {
+	o := newCacheOptions(opts)
+	return &rootCache{
+		r:          client,
+		pr:         prefix,
+		sep:        o.KeySeparator,
+		codec:      o.Codec,
+		defaultTTL: o.DefaultTTL,
+		maxPipe:    o.MaxPipelineSize,
+	}
 }
 
-func New(client redis.UniversalClient, prefix string) Cache {
-	return &rootCache{client, prefix}
+// NewWithCodec is a convenience wrapper around New(client, prefix,
+// WithCodec(codec)).
+func NewWithCodec(client redis.UniversalClient, prefix string, codec Codec) Cache {
+	return New(client, prefix, WithCodec(codec))
 }
 
 type rootCache struct {
-	r  redis.UniversalClient
-	pr string
+	r          redis.UniversalClient
+	pr         string
+	sep        string
+	codec      Codec
+	defaultTTL time.Duration
+	maxPipe    int
 }
 
-func (c *rootCache) Get(id string, res interface{}) error {
-	key := c.pr + id
-	b, err := c.r.Get(key).Bytes()
+func (c *rootCache) key(id string) string {
+	return c.pr + c.sep + id
+}
+
+func (c *rootCache) Get(ctx context.Context, id string, res interface{}) error {
+	b, err := c.GetRaw(ctx, id)
 	if err != nil {
 		return err
 	}
-	err = json.Unmarshal(b, res)
+	err = c.codec.Unmarshal(b, res)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (c *rootCache) Set(id string, res interface{}) error {
-	b, err := json.Marshal(res)
+func (c *rootCache) GetRaw(ctx context.Context, id string) ([]byte, error) {
+	return c.r.Get(ctx, c.key(id)).Bytes()
+}
+
+func (c *rootCache) Set(ctx context.Context, id string, res interface{}) error {
+	return c.SetWithTTL(ctx, id, res, c.defaultTTL)
+}
+
+func (c *rootCache) SetWithTTL(ctx context.Context, id string, res interface{}, ttl time.Duration) error {
+	b, err := c.codec.Marshal(res)
 	if err != nil {
 		return err
 	}
-	return c.SetRaw(id, b)
+	return c.SetRawWithTTL(ctx, id, b, ttl)
+}
+
+func (c *rootCache) SetRaw(ctx context.Context, id string, data []byte) error {
+	return c.SetRawWithTTL(ctx, id, data, c.defaultTTL)
 }
 
-func (c *rootCache) SetRaw(id string, data []byte) error {
-	key := c.pr + id
-	err := c.r.Set(key, data, time.Hour).Err()
+func (c *rootCache) SetRawWithTTL(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	err := c.r.Set(ctx, c.key(id), data, ttl).Err()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (c *rootCache) Expire(id string, at time.Time) error {
-	key := c.pr + id
-	err := c.r.ExpireAt(key, at).Err()
+func (c *rootCache) Expire(ctx context.Context, id string, at time.Time) error {
+	err := c.r.ExpireAt(ctx, c.key(id), at).Err()
 	if err == redis.Nil {
 		return nil
 	} else if err != nil {
@@ -69,88 +120,204 @@ func (c *rootCache) Expire(id string, at time.Time) error {
 	return nil
 }
 
-func (c *rootCache) Delete(id string) error {
-	key := c.pr + id
-	err := c.r.Del(key).Err()
+func (c *rootCache) Delete(ctx context.Context, id string) error {
+	err := c.r.Del(ctx, c.key(id)).Err()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (c *rootCache) Begin(max time.Duration) Cache {
-	return &txnCache{
-		expireAt: time.Now().Add(max),
-		parent:   c,
-		mutates:  make(map[string][]byte),
+func (c *rootCache) GetMulti(ctx context.Context, ids []string, out map[string]interface{}, factory func(id string) interface{}) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = c.key(id)
+	}
+	vals, err := c.r.MGet(ctx, keys...).Result()
+	if err != nil {
+		return err
+	}
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		res := factory(ids[i])
+		if err := c.codec.Unmarshal([]byte(s), res); err != nil {
+			return err
+		}
+		out[ids[i]] = res
 	}
-}
-
-func (c *rootCache) End() error {
 	return nil
 }
 
-type txnCache struct {
-	parent   Cache
-	mutates  map[string][]byte
-	expireAt time.Time
-}
+func (c *rootCache) SetMulti(ctx context.Context, items map[string]interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
 
-func (c *txnCache) Get(id string, res interface{}) error {
-	v, ok := c.mutates[id]
-	if ok {
-		if v == nil {
-			return ErrCacheMiss
+	batchSize := c.maxPipe
+	if batchSize <= 0 {
+		batchSize = len(ids)
+	}
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
 		}
-		err := json.Unmarshal(v, res)
+		batch := ids[start:end]
+		_, err := c.r.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, id := range batch {
+				b, err := c.codec.Marshal(items[id])
+				if err != nil {
+					return err
+				}
+				pipe.Set(ctx, c.key(id), b, c.defaultTTL)
+			}
+			return nil
+		})
 		if err != nil {
 			return err
 		}
-		return nil
 	}
-	return c.parent.Get(id, res)
+	return nil
 }
 
-func (c *txnCache) Set(id string, res interface{}) error {
-	b, err := json.Marshal(res)
-	if err != nil {
-		return err
+func (c *rootCache) DeleteMulti(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = c.key(id)
 	}
-	c.mutates[id] = b
-	return c.parent.Expire(id, c.expireAt)
+	return c.r.Del(ctx, keys...).Err()
 }
 
-func (c *txnCache) Expire(id string, at time.Time) error {
-	return c.parent.Expire(id, at)
-}
+// txnMissingSentinel stands in for "this key did not exist" in both the
+// watched-key snapshots and the write values passed to txnScript, since
+// Lua/Redis have no native nil we can distinguish from an empty string.
+const txnMissingSentinel = "\x00rediscache:missing\x00"
 
-func (c *txnCache) SetRaw(id string, data []byte) error {
-	return c.parent.SetRaw(id, data)
-}
+// txnScript atomically verifies that every watched key still holds the
+// value it held when the transaction first read it (the snapshot taken
+// in txnCache.Get/GetRaw, not merely at commit time - see txn.go), then
+// applies the buffered writes. KEYS is watched keys followed by write
+// keys; ARGV is nWatched, nWrite, the sentinel, the watched snapshots,
+// the write values, and finally a PEXPIREAT timestamp per write key.
+// Returns 1 on success, 0 on conflict.
+var txnScript = redis.NewScript(`
+local nWatched = tonumber(ARGV[1])
+local nWrite = tonumber(ARGV[2])
+local sentinel = ARGV[3]
 
-func (c *txnCache) Delete(id string) error {
-	c.mutates[id] = nil
-	return c.parent.Expire(id, c.expireAt)
-}
+for i = 1, nWatched do
+	local cur = redis.call('GET', KEYS[i])
+	local expected = ARGV[3 + i]
+	if expected == sentinel then
+		if cur then
+			return 0
+		end
+	elseif cur ~= expected then
+		return 0
+	end
+end
 
-func (c *txnCache) Begin(max time.Duration) Cache {
-	return nil
-}
+for i = 1, nWrite do
+	local key = KEYS[nWatched + i]
+	local val = ARGV[3 + nWatched + i]
+	if val == sentinel then
+		redis.call('DEL', key)
+	else
+		local at = ARGV[3 + nWatched + nWrite + i]
+		redis.call('SET', key, val)
+		redis.call('PEXPIREAT', key, at)
+	end
+end
+return 1
+`)
+
+// commitTxn flushes a transaction's buffered mutations and, if any keys
+// were read during the transaction, atomically re-checks them against
+// the values recorded at read time (see txnCache.Get/GetRaw), returning
+// ErrTxnConflict if any changed. A transaction with no buffered
+// mutations does nothing, so its reads are not re-checked. See txn.go.
+func (c *rootCache) commitTxn(ctx context.Context, mutates map[string][]byte, watched map[string]txnRead, ttls map[string]time.Time, expireAt time.Time) error {
+	if len(mutates) == 0 {
+		return nil
+	}
+
+	watchedIDs := make([]string, 0, len(watched))
+	for id := range watched {
+		watchedIDs = append(watchedIDs, id)
+	}
+	writeIDs := make([]string, 0, len(mutates))
+	for id := range mutates {
+		writeIDs = append(writeIDs, id)
+	}
+
+	keys := make([]string, 0, len(watchedIDs)+len(writeIDs))
+	for _, id := range watchedIDs {
+		keys = append(keys, c.key(id))
+	}
+	for _, id := range writeIDs {
+		keys = append(keys, c.key(id))
+	}
 
-func (c *txnCache) End() error {
-	for k, v := range c.mutates {
+	argv := make([]interface{}, 0, 3+len(watchedIDs)+2*len(writeIDs))
+	argv = append(argv, len(watchedIDs), len(writeIDs), txnMissingSentinel)
+	for _, id := range watchedIDs {
+		r := watched[id]
+		if !r.present {
+			argv = append(argv, txnMissingSentinel)
+		} else {
+			argv = append(argv, r.data)
+		}
+	}
+	for _, id := range writeIDs {
+		v := mutates[id]
 		if v == nil {
-			err := c.parent.Delete(k)
-			if err != nil {
-				return err
-			}
+			argv = append(argv, txnMissingSentinel)
 		} else {
-			err := c.parent.SetRaw(k, v)
-			if err != nil {
-				return err
-			}
+			argv = append(argv, v)
 		}
 	}
+	for _, id := range writeIDs {
+		at := expireAt
+		if custom, ok := ttls[id]; ok {
+			at = custom
+		}
+		argv = append(argv, at.UnixMilli())
+	}
+
+	result, err := txnScript.Run(ctx, c.r, keys, argv...).Int64()
+	if err != nil {
+		return err
+	}
+	if result == 0 {
+		return ErrTxnConflict
+	}
 	return nil
 }
 
+func (c *rootCache) Begin(max time.Duration) Cache {
+	return &txnCache{
+		expireAt: time.Now().Add(max),
+		parent:   c,
+		codec:    c.codec,
+		mutates:  make(map[string][]byte),
+		ttls:     make(map[string]time.Time),
+		watched:  make(map[string]txnRead),
+	}
+}
+
+func (c *rootCache) End(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,109 @@
+package rediscache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newBenchCache connects to the Redis instance named by the REDIS_ADDR
+// environment variable, or skips the benchmark if it isn't set. The
+// batched calls below only show their advantage over a real network
+// round trip, so there's no meaningful in-memory equivalent.
+func newBenchCache(b *testing.B) Cache {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		b.Skip("REDIS_ADDR not set, skipping benchmark against a live Redis")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	return New(client, "rediscache-bench:")
+}
+
+func benchIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("key-%d", i)
+	}
+	return ids
+}
+
+// BenchmarkGetLoop warms n keys and then measures reading them back one
+// round trip per key, the way callers had to before GetMulti existed.
+func BenchmarkGetLoop(b *testing.B) {
+	ctx := context.Background()
+	c := newBenchCache(b)
+	ids := benchIDs(100)
+	for _, id := range ids {
+		if err := c.Set(ctx, id, id); err != nil {
+			b.Fatalf("Set: %v", err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, id := range ids {
+			var v string
+			if err := c.Get(ctx, id, &v); err != nil {
+				b.Fatalf("Get: %v", err)
+			}
+		}
+	}
+}
+
+// BenchmarkGetMulti measures the same cache-warm read as BenchmarkGetLoop,
+// batched into a single MGET round trip via GetMulti.
+func BenchmarkGetMulti(b *testing.B) {
+	ctx := context.Background()
+	c := newBenchCache(b)
+	ids := benchIDs(100)
+	for _, id := range ids {
+		if err := c.Set(ctx, id, id); err != nil {
+			b.Fatalf("Set: %v", err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out := make(map[string]interface{}, len(ids))
+		if err := c.GetMulti(ctx, ids, out, func(id string) interface{} { return new(string) }); err != nil {
+			b.Fatalf("GetMulti: %v", err)
+		}
+	}
+}
+
+// BenchmarkSetLoop measures writing n keys one round trip per key.
+func BenchmarkSetLoop(b *testing.B) {
+	ctx := context.Background()
+	c := newBenchCache(b)
+	ids := benchIDs(100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, id := range ids {
+			if err := c.Set(ctx, id, id); err != nil {
+				b.Fatalf("Set: %v", err)
+			}
+		}
+	}
+}
+
+// BenchmarkSetMulti measures the same write, pipelined via SetMulti.
+func BenchmarkSetMulti(b *testing.B) {
+	ctx := context.Background()
+	c := newBenchCache(b)
+	ids := benchIDs(100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		items := make(map[string]interface{}, len(ids))
+		for _, id := range ids {
+			items[id] = id
+		}
+		if err := c.SetMulti(ctx, items); err != nil {
+			b.Fatalf("SetMulti: %v", err)
+		}
+	}
+}
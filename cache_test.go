@@ -0,0 +1,190 @@
+package rediscache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRootCache(t *testing.T) (Cache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return New(client, "test:"), mr
+}
+
+func TestRootCacheCommitTxnAppliesWritesAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newTestRootCache(t)
+
+	txn := c.Begin(time.Minute)
+	if err := txn.Set(ctx, "a", "1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := txn.SetWithTTL(ctx, "b", "2", time.Hour); err != nil {
+		t.Fatalf("SetWithTTL: %v", err)
+	}
+	if err := txn.End(ctx); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+
+	var got string
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get a: %v", err)
+	}
+	if got != "1" {
+		t.Fatalf("got %q, want %q", got, "1")
+	}
+	if err := c.Get(ctx, "b", &got); err != nil {
+		t.Fatalf("Get b: %v", err)
+	}
+	if got != "2" {
+		t.Fatalf("got %q, want %q", got, "2")
+	}
+
+	// SetWithTTL's expiry must be honored, not the transaction's default.
+	ttl := mr.TTL("test:b")
+	if ttl <= time.Minute || ttl > time.Hour {
+		t.Fatalf("TTL(b) = %v, want close to 1h (not the txn's 1m default)", ttl)
+	}
+}
+
+func TestRootCacheCommitTxnDeleteBuffered(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestRootCache(t)
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	txn := c.Begin(time.Minute)
+	if err := txn.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := txn.End(ctx); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+
+	var got string
+	if err := c.Get(ctx, "a", &got); err != ErrCacheMiss {
+		t.Fatalf("Get after End: got err %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestRootCacheCommitTxnConflictOnOutOfBandWrite(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestRootCache(t)
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	txn := c.Begin(time.Minute)
+	var got string
+	if err := txn.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if err := txn.Set(ctx, "a", "2"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// Mutate the watched key out-of-band, as another client would, after
+	// the transaction's read but before its End.
+	if err := c.Set(ctx, "a", "interloper"); err != nil {
+		t.Fatalf("out-of-band Set: %v", err)
+	}
+
+	if err := txn.End(ctx); err != ErrTxnConflict {
+		t.Fatalf("End: got err %v, want ErrTxnConflict", err)
+	}
+
+	// The conflicting write must not have been clobbered by the failed
+	// transaction.
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "interloper" {
+		t.Fatalf("got %q, want %q", got, "interloper")
+	}
+}
+
+func TestRootCacheCommitTxnConflictOnOutOfBandDelete(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestRootCache(t)
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	txn := c.Begin(time.Minute)
+	var got string
+	if err := txn.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if err := txn.Set(ctx, "a", "2"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Fatalf("out-of-band Delete: %v", err)
+	}
+
+	if err := txn.End(ctx); err != ErrTxnConflict {
+		t.Fatalf("End: got err %v, want ErrTxnConflict", err)
+	}
+}
+
+func TestRootCacheCommitTxnConflictOnKeyThatDidNotExist(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestRootCache(t)
+
+	txn := c.Begin(time.Minute)
+	var got string
+	if err := txn.Get(ctx, "a", &got); err != ErrCacheMiss {
+		t.Fatalf("Get: got err %v, want ErrCacheMiss", err)
+	}
+	if err := txn.Set(ctx, "a", "2"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// Another client creates the key after the transaction observed it
+	// as absent; the sentinel-based "missing" check must catch this.
+	if err := c.Set(ctx, "a", "interloper"); err != nil {
+		t.Fatalf("out-of-band Set: %v", err)
+	}
+
+	if err := txn.End(ctx); err != ErrTxnConflict {
+		t.Fatalf("End: got err %v, want ErrTxnConflict", err)
+	}
+}
+
+func TestRootCacheCommitTxnNoConflictWhenUnrelatedKeyChanges(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestRootCache(t)
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	txn := c.Begin(time.Minute)
+	var got string
+	if err := txn.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if err := txn.Set(ctx, "a", "2"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if err := c.Set(ctx, "other", "unrelated"); err != nil {
+		t.Fatalf("Set other: %v", err)
+	}
+
+	if err := txn.End(ctx); err != nil {
+		t.Fatalf("End: %v (unrelated key change must not conflict)", err)
+	}
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "2" {
+		t.Fatalf("got %q, want %q", got, "2")
+	}
+}
@@ -0,0 +1,103 @@
+package rediscache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec marshals and unmarshals the values stored by a Cache. Get and Set
+// route through the Cache's configured Codec; SetRaw always takes bytes
+// as-is, bypassing the codec entirely.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes values with encoding/json. It is the default codec
+// used by New when no Option overrides it.
+var JSONCodec Codec = jsonCodec{}
+
+// GobCodec encodes values with encoding/gob. Prefer it over JSONCodec for
+// cyclic or interface-heavy structs that gob handles and json does not.
+var GobCodec Codec = gobCodec{}
+
+// CBORCodec encodes values as CBOR, which is typically smaller and faster
+// to (de)serialize than JSON for the same data.
+var CBORCodec Codec = cborCodec{}
+
+// MsgpackCodec encodes values with MessagePack, another compact
+// alternative to JSON.
+var MsgpackCodec Codec = msgpackCodec{}
+
+// ProtobufCodec encodes values using protocol buffers. It only accepts
+// values implementing proto.Message; Marshal/Unmarshal return an error
+// for anything else.
+var ProtobufCodec Codec = protobufCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type cborCodec struct{}
+
+func (cborCodec) Marshal(v interface{}) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+func (cborCodec) Unmarshal(data []byte, v interface{}) error {
+	return cbor.Unmarshal(data, v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("rediscache: ProtobufCodec requires a proto.Message, got %T", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("rediscache: ProtobufCodec requires a proto.Message, got %T", v)
+	}
+	return proto.Unmarshal(data, m)
+}
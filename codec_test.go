@@ -0,0 +1,48 @@
+package rediscache
+
+import "testing"
+
+type codecFixture struct {
+	Name  string
+	Count int
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	codecs := map[string]Codec{
+		"json":    JSONCodec,
+		"gob":     GobCodec,
+		"cbor":    CBORCodec,
+		"msgpack": MsgpackCodec,
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			in := codecFixture{Name: "widget", Count: 3}
+
+			b, err := codec.Marshal(in)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			var out codecFixture
+			if err := codec.Unmarshal(b, &out); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if out != in {
+				t.Fatalf("got %+v, want %+v", out, in)
+			}
+		})
+	}
+}
+
+func TestProtobufCodecRejectsNonProtoMessage(t *testing.T) {
+	_, err := ProtobufCodec.Marshal(codecFixture{Name: "widget"})
+	if err == nil {
+		t.Fatal("Marshal: expected error for a non-proto.Message value, got nil")
+	}
+
+	err = ProtobufCodec.Unmarshal([]byte{}, &codecFixture{})
+	if err == nil {
+		t.Fatal("Unmarshal: expected error for a non-proto.Message value, got nil")
+	}
+}
@@ -0,0 +1,58 @@
+package rediscache
+
+import (
+	"context"
+	"time"
+)
+
+// LegacyCache is the pre-context Cache API (every call implicitly used
+// context.Background()). It exists so callers that have not yet threaded
+// a context through to their cache calls can keep building against this
+// package; new code should use Cache directly.
+type LegacyCache interface {
+	Get(id string, res interface{}) error
+	Set(id string, res interface{}) error
+	SetRaw(id string, data []byte) error
+	Delete(id string) error
+	Expire(id string, at time.Time) error
+	Begin(max time.Duration) LegacyCache
+	End() error
+}
+
+// Legacy adapts a Cache to the pre-context LegacyCache API, issuing every
+// call with context.Background().
+func Legacy(c Cache) LegacyCache {
+	return legacyCache{c}
+}
+
+type legacyCache struct {
+	c Cache
+}
+
+func (l legacyCache) Get(id string, res interface{}) error {
+	return l.c.Get(context.Background(), id, res)
+}
+
+func (l legacyCache) Set(id string, res interface{}) error {
+	return l.c.Set(context.Background(), id, res)
+}
+
+func (l legacyCache) SetRaw(id string, data []byte) error {
+	return l.c.SetRaw(context.Background(), id, data)
+}
+
+func (l legacyCache) Delete(id string) error {
+	return l.c.Delete(context.Background(), id)
+}
+
+func (l legacyCache) Expire(id string, at time.Time) error {
+	return l.c.Expire(context.Background(), id, at)
+}
+
+func (l legacyCache) Begin(max time.Duration) LegacyCache {
+	return Legacy(l.c.Begin(max))
+}
+
+func (l legacyCache) End() error {
+	return l.c.End(context.Background())
+}
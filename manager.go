@@ -0,0 +1,70 @@
+package rediscache
+
+import (
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Manager hands out named Cache instances backed by a single adapter
+// (redis, memory, or null), so a process can address multiple logical
+// caches ("users", "sessions", ...) through one entry point while each
+// namespace gets its own keyspace/storage.
+type Manager interface {
+	// Cache returns the Cache for namespace, creating it on first use.
+	// Repeated calls with the same namespace return the same instance.
+	Cache(namespace string) Cache
+}
+
+// NewManager builds a Manager that lazily creates one Cache per
+// namespace using factory. It is the building block behind
+// NewRedisManager, NewMemoryManager, and NewNullManager.
+func NewManager(factory func(namespace string) Cache) Manager {
+	return &manager{
+		factory: factory,
+		caches:  make(map[string]Cache),
+	}
+}
+
+type manager struct {
+	mu      sync.Mutex
+	factory func(namespace string) Cache
+	caches  map[string]Cache
+}
+
+func (m *manager) Cache(namespace string) Cache {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.caches[namespace]; ok {
+		return c
+	}
+	c := m.factory(namespace)
+	m.caches[namespace] = c
+	return c
+}
+
+// NewRedisManager builds a Manager whose Cache instances are New(client,
+// namespace, opts...), i.e. one Redis-backed Cache per namespace sharing
+// client but keyed under a distinct prefix.
+func NewRedisManager(client redis.UniversalClient, opts ...Option) Manager {
+	return NewManager(func(namespace string) Cache {
+		return New(client, namespace, opts...)
+	})
+}
+
+// NewMemoryManager builds a Manager whose Cache instances are
+// independent in-process NewMemory caches, one per namespace.
+func NewMemoryManager(opts ...MemoryOption) Manager {
+	return NewManager(func(namespace string) Cache {
+		return NewMemory(opts...)
+	})
+}
+
+// NewNullManager builds a Manager whose Cache instances are all
+// NewNull(), useful for disabling caching across every namespace at
+// once.
+func NewNullManager() Manager {
+	return NewManager(func(namespace string) Cache {
+		return NewNull()
+	})
+}
@@ -0,0 +1,224 @@
+package rediscache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMemoryTTL        = time.Hour
+	defaultMemoryMaxEntries = 10000
+)
+
+// MemoryOptions holds the tunable settings for a Cache created via
+// NewMemory. Use the WithMemory* functions rather than constructing this
+// directly.
+type MemoryOptions struct {
+	Codec Codec
+
+	// TTL is the expiration applied by Set/SetRaw when no per-call TTL
+	// is given. Defaults to time.Hour.
+	TTL time.Duration
+
+	// MaxEntries bounds how many items the cache holds before evicting
+	// the least recently used entry. Defaults to 10000.
+	MaxEntries int
+}
+
+// MemoryOption configures a MemoryOptions value. Pass zero or more to
+// NewMemory.
+type MemoryOption func(*MemoryOptions)
+
+// WithMemoryCodec overrides the codec used to (de)serialize values
+// passed to Get/Set. The default is JSONCodec.
+func WithMemoryCodec(codec Codec) MemoryOption {
+	return func(o *MemoryOptions) {
+		o.Codec = codec
+	}
+}
+
+// WithMemoryTTL overrides the expiration applied by Set/SetRaw when no
+// per-call TTL is given.
+func WithMemoryTTL(ttl time.Duration) MemoryOption {
+	return func(o *MemoryOptions) {
+		o.TTL = ttl
+	}
+}
+
+// WithMemoryMaxEntries overrides how many items the cache holds before
+// evicting the least recently used entry.
+func WithMemoryMaxEntries(n int) MemoryOption {
+	return func(o *MemoryOptions) {
+		o.MaxEntries = n
+	}
+}
+
+// NewMemory constructs an in-process Cache backed by a bounded LRU with
+// per-entry TTLs. It is useful for unit tests that shouldn't depend on a
+// running Redis, and as the near tier of Tiered.
+func NewMemory(opts ...MemoryOption) Cache {
+	o := MemoryOptions{
+		Codec:      JSONCodec,
+		TTL:        defaultMemoryTTL,
+		MaxEntries: defaultMemoryMaxEntries,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &memoryCache{
+		codec:      o.Codec,
+		defaultTTL: o.TTL,
+		maxEntries: o.MaxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+type memoryEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+type memoryCache struct {
+	mu         sync.Mutex
+	codec      Codec
+	defaultTTL time.Duration
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List
+}
+
+func (c *memoryCache) Get(ctx context.Context, id string, res interface{}) error {
+	data, err := c.GetRaw(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal(data, res)
+}
+
+func (c *memoryCache) GetRaw(ctx context.Context, id string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[id]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	entry := el.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeLocked(el)
+		return nil, ErrCacheMiss
+	}
+	c.order.MoveToFront(el)
+	return entry.data, nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, id string, res interface{}) error {
+	return c.SetWithTTL(ctx, id, res, c.defaultTTL)
+}
+
+func (c *memoryCache) SetWithTTL(ctx context.Context, id string, res interface{}, ttl time.Duration) error {
+	b, err := c.codec.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return c.SetRawWithTTL(ctx, id, b, ttl)
+}
+
+func (c *memoryCache) SetRaw(ctx context.Context, id string, data []byte) error {
+	return c.SetRawWithTTL(ctx, id, data, c.defaultTTL)
+}
+
+func (c *memoryCache) SetRawWithTTL(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if el, ok := c.items[id]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.data = data
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	el := c.order.PushFront(&memoryEntry{key: id, data: data, expiresAt: expiresAt})
+	c.items[id] = el
+	for c.order.Len() > c.maxEntries {
+		c.removeLocked(c.order.Back())
+	}
+	return nil
+}
+
+func (c *memoryCache) Expire(ctx context.Context, id string, at time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[id]; ok {
+		el.Value.(*memoryEntry).expiresAt = at
+	}
+	return nil
+}
+
+func (c *memoryCache) Delete(ctx context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[id]; ok {
+		c.removeLocked(el)
+	}
+	return nil
+}
+
+func (c *memoryCache) GetMulti(ctx context.Context, ids []string, out map[string]interface{}, factory func(id string) interface{}) error {
+	for _, id := range ids {
+		res := factory(id)
+		if err := c.Get(ctx, id, res); err != nil {
+			if err == ErrCacheMiss {
+				continue
+			}
+			return err
+		}
+		out[id] = res
+	}
+	return nil
+}
+
+func (c *memoryCache) SetMulti(ctx context.Context, items map[string]interface{}) error {
+	for id, res := range items {
+		if err := c.Set(ctx, id, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *memoryCache) DeleteMulti(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if err := c.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *memoryCache) removeLocked(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*memoryEntry).key)
+}
+
+func (c *memoryCache) Begin(max time.Duration) Cache {
+	return &txnCache{
+		expireAt: time.Now().Add(max),
+		parent:   c,
+		codec:    c.codec,
+		mutates:  make(map[string][]byte),
+		ttls:     make(map[string]time.Time),
+		watched:  make(map[string]txnRead),
+	}
+}
+
+func (c *memoryCache) End(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package rediscache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheGetSet(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+
+	if err := c.Set(ctx, "a", "hello"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	var got string
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "hello" {
+		t.Fatalf("got %q, want %q", got, "hello")
+	}
+
+	if err := c.Get(ctx, "missing", &got); err != ErrCacheMiss {
+		t.Fatalf("Get(missing): got err %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestMemoryCacheTTLExpiry(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+
+	if err := c.SetWithTTL(ctx, "a", "hello", 10*time.Millisecond); err != nil {
+		t.Fatalf("SetWithTTL: %v", err)
+	}
+
+	var got string
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get before expiry: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if err := c.Get(ctx, "a", &got); err != ErrCacheMiss {
+		t.Fatalf("Get after expiry: got err %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestMemoryCacheLRUEviction(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(WithMemoryMaxEntries(2))
+
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatalf("Set a: %v", err)
+	}
+	if err := c.Set(ctx, "b", "2"); err != nil {
+		t.Fatalf("Set b: %v", err)
+	}
+	// Touch "a" so "b" becomes the least recently used entry.
+	var tmp string
+	if err := c.Get(ctx, "a", &tmp); err != nil {
+		t.Fatalf("Get a: %v", err)
+	}
+	if err := c.Set(ctx, "c", "3"); err != nil {
+		t.Fatalf("Set c: %v", err)
+	}
+
+	if err := c.Get(ctx, "b", &tmp); err != ErrCacheMiss {
+		t.Fatalf("Get b: got err %v, want ErrCacheMiss (should have been evicted)", err)
+	}
+	if err := c.Get(ctx, "a", &tmp); err != nil {
+		t.Fatalf("Get a: %v (should not have been evicted)", err)
+	}
+	if err := c.Get(ctx, "c", &tmp); err != nil {
+		t.Fatalf("Get c: %v (should not have been evicted)", err)
+	}
+}
+
+func TestMemoryCacheDelete(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	var got string
+	if err := c.Get(ctx, "a", &got); err != ErrCacheMiss {
+		t.Fatalf("Get after Delete: got err %v, want ErrCacheMiss", err)
+	}
+}
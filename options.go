@@ -0,0 +1,79 @@
+package rediscache
+
+import "time"
+
+const (
+	// defaultTTL matches the expiration New used before per-cache TTLs
+	// were configurable.
+	defaultTTL = time.Hour
+
+	// defaultMaxPipelineSize bounds how many commands SetMulti batches
+	// into a single pipeline round trip. GetMulti's MGET is not split.
+	defaultMaxPipelineSize = 100
+)
+
+// CacheOptions holds the tunable settings for a Cache created via New.
+// Use the With* functions rather than constructing this directly.
+type CacheOptions struct {
+	Codec Codec
+
+	// DefaultTTL is the expiration applied by Set/SetRaw when no
+	// per-call TTL is given. Defaults to time.Hour.
+	DefaultTTL time.Duration
+
+	// KeySeparator is inserted between prefix and id when building
+	// Redis keys. Defaults to "" (prefix and id are concatenated
+	// directly, matching the pre-existing behavior).
+	KeySeparator string
+
+	// MaxPipelineSize bounds how many commands a single pipelined
+	// round trip may contain. Defaults to 100.
+	MaxPipelineSize int
+}
+
+// Option configures a CacheOptions value. Pass zero or more to New.
+type Option func(*CacheOptions)
+
+// WithCodec overrides the codec used to (de)serialize values passed to
+// Get/Set. The default is JSONCodec.
+func WithCodec(codec Codec) Option {
+	return func(o *CacheOptions) {
+		o.Codec = codec
+	}
+}
+
+// WithDefaultTTL overrides the expiration applied by Set/SetRaw when no
+// per-call TTL is given.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(o *CacheOptions) {
+		o.DefaultTTL = ttl
+	}
+}
+
+// WithKeySeparator overrides the string inserted between prefix and id
+// when building Redis keys.
+func WithKeySeparator(sep string) Option {
+	return func(o *CacheOptions) {
+		o.KeySeparator = sep
+	}
+}
+
+// WithMaxPipelineSize overrides how many commands a single pipelined
+// round trip may contain.
+func WithMaxPipelineSize(n int) Option {
+	return func(o *CacheOptions) {
+		o.MaxPipelineSize = n
+	}
+}
+
+func newCacheOptions(opts []Option) CacheOptions {
+	o := CacheOptions{
+		Codec:           JSONCodec,
+		DefaultTTL:      defaultTTL,
+		MaxPipelineSize: defaultMaxPipelineSize,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,381 @@
+package rediscache
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// jitterFraction is how much a TTL may be shortened by to avoid many
+// keys expiring in lockstep (the "thundering herd" on expiry problem).
+const jitterFraction = 0.1
+
+func jitter(ttl time.Duration) time.Duration {
+	maxDelta := time.Duration(float64(ttl) * jitterFraction)
+	if maxDelta <= 0 {
+		return ttl
+	}
+	return ttl - time.Duration(rand.Int63n(int64(maxDelta)))
+}
+
+// TieredCache is a Cache that consults an in-process near tier before
+// falling back to a far tier (typically Redis), populating the near
+// tier on the way back. Concurrent misses for the same id are collapsed
+// with singleflight so only one request reaches the far tier.
+type TieredCache struct {
+	near, far Cache
+	nearTTL   time.Duration
+	codec     Codec
+	sf        singleflight.Group
+
+	client  redis.UniversalClient
+	channel string
+	cancel  context.CancelFunc
+}
+
+// Tiered builds a TieredCache from near (e.g. NewMemory()) and far (e.g.
+// a Redis-backed Cache from New). If far was built with New/NewWithCodec,
+// Tiered also subscribes to a pub/sub channel so that Set/Delete calls
+// made by other processes through their own TieredCache evict this
+// process's near tier; call Close when done with the TieredCache to
+// stop that subscription.
+func Tiered(near, far Cache, opts ...TieredOption) *TieredCache {
+	o := tieredOptions{codec: JSONCodec, nearTTL: defaultMemoryTTL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	t := &TieredCache{near: near, far: far, nearTTL: o.nearTTL, codec: o.codec}
+	if rc, ok := far.(*rootCache); ok {
+		t.client = rc.r
+		t.channel = rc.pr + "invalidate"
+		ctx, cancel := context.WithCancel(context.Background())
+		t.cancel = cancel
+		go t.listenInvalidations(ctx)
+	}
+	return t
+}
+
+// Close stops the background subscription used to evict the near tier
+// when other processes write through their own TieredCache. It is a
+// no-op if far isn't Redis-backed. It does not close near or far.
+func (t *TieredCache) Close() error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	return nil
+}
+
+// TieredOption configures a TieredCache. Pass zero or more to Tiered.
+type TieredOption func(*tieredOptions)
+
+type tieredOptions struct {
+	codec   Codec
+	nearTTL time.Duration
+}
+
+// WithTieredCodec overrides the codec used to decode values shared
+// across singleflight callers. It should match the codec far was built
+// with. Defaults to JSONCodec.
+func WithTieredCodec(codec Codec) TieredOption {
+	return func(o *tieredOptions) {
+		o.codec = codec
+	}
+}
+
+// WithTieredNearTTL overrides how long an entry populated from far is
+// kept in the near tier. Defaults to one hour; the effective TTL is
+// jittered to avoid synchronized expirations.
+func WithTieredNearTTL(ttl time.Duration) TieredOption {
+	return func(o *tieredOptions) {
+		o.nearTTL = ttl
+	}
+}
+
+func (t *TieredCache) Get(ctx context.Context, id string, res interface{}) error {
+	raw, err := t.GetRaw(ctx, id)
+	if err != nil {
+		return err
+	}
+	return t.codec.Unmarshal(raw, res)
+}
+
+func (t *TieredCache) GetRaw(ctx context.Context, id string) ([]byte, error) {
+	raw, err := t.near.GetRaw(ctx, id)
+	if err == nil {
+		return raw, nil
+	}
+	if err != ErrCacheMiss {
+		return nil, err
+	}
+
+	v, err, _ := t.sf.Do(id, func() (interface{}, error) {
+		raw, err := t.far.GetRaw(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.near.SetRawWithTTL(ctx, id, raw, jitter(t.nearTTL)); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+func (t *TieredCache) Set(ctx context.Context, id string, res interface{}) error {
+	b, err := t.codec.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return t.SetRaw(ctx, id, b)
+}
+
+func (t *TieredCache) SetWithTTL(ctx context.Context, id string, res interface{}, ttl time.Duration) error {
+	b, err := t.codec.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return t.SetRawWithTTL(ctx, id, b, ttl)
+}
+
+func (t *TieredCache) SetRaw(ctx context.Context, id string, data []byte) error {
+	if err := t.far.SetRaw(ctx, id, data); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, id)
+}
+
+func (t *TieredCache) SetRawWithTTL(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	if err := t.far.SetRawWithTTL(ctx, id, data, ttl); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, id)
+}
+
+func (t *TieredCache) Delete(ctx context.Context, id string) error {
+	if err := t.far.Delete(ctx, id); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, id)
+}
+
+func (t *TieredCache) Expire(ctx context.Context, id string, at time.Time) error {
+	return t.far.Expire(ctx, id, at)
+}
+
+// GetMulti fetches each id in turn via Get, so near-tier hits are still
+// served without touching far. It does not batch far-tier misses into a
+// single round trip; callers needing that should use far.GetMulti
+// directly.
+func (t *TieredCache) GetMulti(ctx context.Context, ids []string, out map[string]interface{}, factory func(id string) interface{}) error {
+	for _, id := range ids {
+		res := factory(id)
+		if err := t.Get(ctx, id, res); err != nil {
+			if err == ErrCacheMiss {
+				continue
+			}
+			return err
+		}
+		out[id] = res
+	}
+	return nil
+}
+
+func (t *TieredCache) SetMulti(ctx context.Context, items map[string]interface{}) error {
+	for id, res := range items {
+		if err := t.Set(ctx, id, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *TieredCache) DeleteMulti(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if err := t.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Begin starts a transaction on the far tier, wrapped so that a
+// successful End evicts (and publishes invalidation for) every key the
+// transaction wrote, keeping the near tier from serving stale data
+// until nearTTL expires. Reads made through the returned Cache are not
+// served from the near tier.
+func (t *TieredCache) Begin(max time.Duration) Cache {
+	return &tieredTxn{t: t, inner: t.far.Begin(max), touched: make(map[string]struct{})}
+}
+
+func (t *TieredCache) End(ctx context.Context) error {
+	return t.far.End(ctx)
+}
+
+// tieredTxn wraps the Cache returned by far.Begin so that committing the
+// transaction also invalidates the near tier for every key it wrote.
+// Nested Begin/End (see txnCache) is mirrored here: a nested tieredTxn's
+// End merges its touched keys into the parent instead of invalidating
+// anything itself, so invalidation only happens once the outermost End
+// actually commits.
+type tieredTxn struct {
+	t       *TieredCache
+	parent  *tieredTxn
+	inner   Cache
+	touched map[string]struct{}
+}
+
+func (tt *tieredTxn) touch(id string) {
+	tt.touched[id] = struct{}{}
+}
+
+func (tt *tieredTxn) Get(ctx context.Context, id string, res interface{}) error {
+	return tt.inner.Get(ctx, id, res)
+}
+
+func (tt *tieredTxn) GetRaw(ctx context.Context, id string) ([]byte, error) {
+	return tt.inner.GetRaw(ctx, id)
+}
+
+func (tt *tieredTxn) Set(ctx context.Context, id string, res interface{}) error {
+	tt.touch(id)
+	return tt.inner.Set(ctx, id, res)
+}
+
+func (tt *tieredTxn) SetWithTTL(ctx context.Context, id string, res interface{}, ttl time.Duration) error {
+	tt.touch(id)
+	return tt.inner.SetWithTTL(ctx, id, res, ttl)
+}
+
+func (tt *tieredTxn) SetRaw(ctx context.Context, id string, data []byte) error {
+	tt.touch(id)
+	return tt.inner.SetRaw(ctx, id, data)
+}
+
+func (tt *tieredTxn) SetRawWithTTL(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	tt.touch(id)
+	return tt.inner.SetRawWithTTL(ctx, id, data, ttl)
+}
+
+func (tt *tieredTxn) Delete(ctx context.Context, id string) error {
+	tt.touch(id)
+	return tt.inner.Delete(ctx, id)
+}
+
+func (tt *tieredTxn) Expire(ctx context.Context, id string, at time.Time) error {
+	return tt.inner.Expire(ctx, id, at)
+}
+
+func (tt *tieredTxn) GetMulti(ctx context.Context, ids []string, out map[string]interface{}, factory func(id string) interface{}) error {
+	return tt.inner.GetMulti(ctx, ids, out, factory)
+}
+
+func (tt *tieredTxn) SetMulti(ctx context.Context, items map[string]interface{}) error {
+	for id := range items {
+		tt.touch(id)
+	}
+	return tt.inner.SetMulti(ctx, items)
+}
+
+func (tt *tieredTxn) DeleteMulti(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		tt.touch(id)
+	}
+	return tt.inner.DeleteMulti(ctx, ids)
+}
+
+func (tt *tieredTxn) Begin(max time.Duration) Cache {
+	return &tieredTxn{t: tt.t, parent: tt, inner: tt.inner.Begin(max), touched: make(map[string]struct{})}
+}
+
+func (tt *tieredTxn) End(ctx context.Context) error {
+	if err := tt.inner.End(ctx); err != nil {
+		return err
+	}
+	if tt.parent != nil {
+		for id := range tt.touched {
+			tt.parent.touched[id] = struct{}{}
+		}
+		return nil
+	}
+	for id := range tt.touched {
+		if err := tt.t.invalidate(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetOrLoad returns the cached value for id, or calls loader to produce
+// it on a miss, caching the result for subsequent callers: in far with a
+// jittered ttl, and in near with a jittered nearTTL. Concurrent loads for the same id are
+// collapsed via singleflight so loader runs at most once per miss.
+// loader must return a value assignable to res.
+func (t *TieredCache) GetOrLoad(ctx context.Context, id string, res interface{}, loader func() (interface{}, error), ttl time.Duration) error {
+	err := t.Get(ctx, id, res)
+	if err == nil {
+		return nil
+	}
+	if err != ErrCacheMiss {
+		return err
+	}
+
+	v, err, _ := t.sf.Do("load:"+id, func() (interface{}, error) {
+		val, err := loader()
+		if err != nil {
+			return nil, err
+		}
+		jittered := jitter(ttl)
+		if err := t.far.SetWithTTL(ctx, id, val, jittered); err != nil {
+			return nil, err
+		}
+		if err := t.near.SetWithTTL(ctx, id, val, jitter(t.nearTTL)); err != nil {
+			return nil, err
+		}
+		return val, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	reflect.ValueOf(res).Elem().Set(reflect.ValueOf(v))
+	return nil
+}
+
+// invalidate evicts id from the near tier and, if far is Redis-backed,
+// publishes an invalidation so other processes' TieredCache instances
+// evict their own near tier too.
+func (t *TieredCache) invalidate(ctx context.Context, id string) error {
+	if err := t.near.Delete(ctx, id); err != nil {
+		return err
+	}
+	if t.client == nil {
+		return nil
+	}
+	return t.client.Publish(ctx, t.channel, id).Err()
+}
+
+func (t *TieredCache) listenInvalidations(ctx context.Context) {
+	sub := t.client.Subscribe(ctx, t.channel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			t.near.Delete(ctx, msg.Payload)
+		}
+	}
+}
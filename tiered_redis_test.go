@@ -0,0 +1,123 @@
+package rediscache
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRootCacheForTiered(t *testing.T) Cache {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return New(client, "test:")
+}
+
+// waitFor polls fn until it returns true or timeout elapses, for
+// assertions against the async pub/sub invalidation below.
+func waitFor(t *testing.T, timeout time.Duration, fn func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		if fn() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTieredCachePublishesInvalidationAcrossProcesses(t *testing.T) {
+	ctx := context.Background()
+	far := newTestRootCacheForTiered(t)
+
+	nearA, nearB := NewMemory(), NewMemory()
+	tcA := Tiered(nearA, far)
+	defer tcA.Close()
+	tcB := Tiered(nearB, far)
+	defer tcB.Close()
+
+	// Warm both processes' near tiers with the same value.
+	if err := far.Set(ctx, "a", "v1"); err != nil {
+		t.Fatalf("Set far: %v", err)
+	}
+	var got string
+	if err := tcA.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("tcA.Get: %v", err)
+	}
+	if err := tcB.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("tcB.Get: %v", err)
+	}
+	if err := nearA.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("nearA should be warm: %v", err)
+	}
+	if err := nearB.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("nearB should be warm: %v", err)
+	}
+
+	// A write through tcA must publish an invalidation that evicts
+	// tcB's near tier too, not just tcA's own.
+	if err := tcA.Set(ctx, "a", "v2"); err != nil {
+		t.Fatalf("tcA.Set: %v", err)
+	}
+
+	ok := waitFor(t, time.Second, func() bool {
+		return nearB.Get(ctx, "a", &got) == ErrCacheMiss
+	})
+	if !ok {
+		t.Fatal("nearB entry was not evicted by tcA's invalidation within timeout")
+	}
+
+	if err := tcB.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("tcB.Get after invalidation: %v", err)
+	}
+	if got != "v2" {
+		t.Fatalf("got %q, want %q", got, "v2")
+	}
+}
+
+func TestTieredCacheCloseStopsSubscriberGoroutine(t *testing.T) {
+	ctx := context.Background()
+	far := newTestRootCacheForTiered(t)
+
+	before := runtime.NumGoroutine()
+
+	tc := Tiered(NewMemory(), far)
+	if !waitFor(t, time.Second, func() bool {
+		return runtime.NumGoroutine() > before
+	}) {
+		t.Fatal("subscriber goroutine never started")
+	}
+
+	if err := tc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if !waitFor(t, time.Second, func() bool {
+		return runtime.NumGoroutine() <= before
+	}) {
+		t.Fatalf("goroutine count %d did not return to baseline %d after Close", runtime.NumGoroutine(), before)
+	}
+
+	// Close must be safe to call again and must not resurrect the
+	// subscription.
+	if err := tc.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := runtime.NumGoroutine(); got > before {
+		t.Fatalf("goroutine count %d exceeds baseline %d after second Close", got, before)
+	}
+
+	// Invalidations published after Close must not panic or deadlock on
+	// the now-closed subscription.
+	if err := tc.Set(ctx, "a", "v1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+}
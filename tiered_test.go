@@ -0,0 +1,177 @@
+package rediscache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingCache wraps a Cache and counts calls to GetRaw, optionally
+// blocking until release is closed so concurrent callers can be made to
+// overlap deterministically.
+type countingCache struct {
+	Cache
+	gets    int32
+	release chan struct{}
+}
+
+func (c *countingCache) GetRaw(ctx context.Context, id string) ([]byte, error) {
+	atomic.AddInt32(&c.gets, 1)
+	if c.release != nil {
+		<-c.release
+	}
+	return c.Cache.GetRaw(ctx, id)
+}
+
+func TestTieredCacheNearHitSkipsFar(t *testing.T) {
+	ctx := context.Background()
+	near, far := NewMemory(), NewMemory()
+	if err := far.Set(ctx, "a", "far-value"); err != nil {
+		t.Fatalf("Set far: %v", err)
+	}
+	if err := near.Set(ctx, "a", "near-value"); err != nil {
+		t.Fatalf("Set near: %v", err)
+	}
+
+	counting := &countingCache{Cache: far}
+	tc := Tiered(near, counting)
+
+	var got string
+	if err := tc.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "near-value" {
+		t.Fatalf("got %q, want %q", got, "near-value")
+	}
+	if counting.gets != 0 {
+		t.Fatalf("far.GetRaw called %d times, want 0", counting.gets)
+	}
+}
+
+func TestTieredCacheSinglefightDedupsConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	near, far := NewMemory(), NewMemory()
+	if err := far.Set(ctx, "a", "far-value"); err != nil {
+		t.Fatalf("Set far: %v", err)
+	}
+
+	counting := &countingCache{Cache: far, release: make(chan struct{})}
+	tc := Tiered(near, counting)
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tc.Get(ctx, "a", &results[i])
+		}(i)
+	}
+
+	// Give every goroutine a chance to start and block inside GetRaw
+	// before letting any of them complete.
+	time.Sleep(20 * time.Millisecond)
+	close(counting.release)
+	wg.Wait()
+
+	for i, got := range results {
+		if got != "far-value" {
+			t.Fatalf("result[%d] = %q, want %q", i, got, "far-value")
+		}
+	}
+	if counting.gets != 1 {
+		t.Fatalf("far.GetRaw called %d times, want 1 (singleflight should dedup)", counting.gets)
+	}
+}
+
+func TestTieredCacheSetInvalidatesNearTier(t *testing.T) {
+	ctx := context.Background()
+	near, far := NewMemory(), NewMemory()
+	tc := Tiered(near, far)
+
+	if err := tc.Set(ctx, "a", "v1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	var got string
+	if err := tc.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "v1" {
+		t.Fatalf("got %q, want %q", got, "v1")
+	}
+	// The near tier should now be populated with v1.
+	if err := near.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("near.Get: %v", err)
+	}
+	if got != "v1" {
+		t.Fatalf("near got %q, want %q", got, "v1")
+	}
+
+	if err := tc.Set(ctx, "a", "v2"); err != nil {
+		t.Fatalf("Set v2: %v", err)
+	}
+	// Set must have evicted the stale near-tier entry.
+	if err := near.Get(ctx, "a", &got); err != ErrCacheMiss {
+		t.Fatalf("near.Get after Set v2: got err %v, want ErrCacheMiss", err)
+	}
+
+	if err := tc.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get v2: %v", err)
+	}
+	if got != "v2" {
+		t.Fatalf("got %q, want %q", got, "v2")
+	}
+}
+
+func TestTieredCacheTxnInvalidatesNearTierOnlyAfterOuterEnd(t *testing.T) {
+	ctx := context.Background()
+	near, far := NewMemory(), NewMemory()
+	tc := Tiered(near, far)
+
+	if err := tc.Set(ctx, "a", "v1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	var got string
+	if err := tc.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	outer := tc.Begin(time.Minute)
+	inner := outer.Begin(time.Minute)
+	if err := inner.Set(ctx, "a", "v2"); err != nil {
+		t.Fatalf("inner.Set: %v", err)
+	}
+	if err := inner.End(ctx); err != nil {
+		t.Fatalf("inner.End: %v", err)
+	}
+
+	// Nested End must not invalidate the near tier yet.
+	if err := near.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("near.Get after inner.End: %v (should not be invalidated yet)", err)
+	}
+
+	if err := outer.End(ctx); err != nil {
+		t.Fatalf("outer.End: %v", err)
+	}
+
+	if err := near.Get(ctx, "a", &got); err != ErrCacheMiss {
+		t.Fatalf("near.Get after outer.End: got err %v, want ErrCacheMiss", err)
+	}
+	if err := tc.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "v2" {
+		t.Fatalf("got %q, want %q", got, "v2")
+	}
+}
+
+func TestJitterDoesNotPanicOnSmallTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, 1, 5, time.Nanosecond} {
+		if got := jitter(ttl); got < 0 {
+			t.Fatalf("jitter(%v) = %v, want >= 0", ttl, got)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package rediscache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTxnConflict is returned by End when a key read during the
+// transaction (via Get/GetRaw) was modified by another client before the
+// transaction committed. Callers should retry the transaction from the
+// top.
+var ErrTxnConflict = errors.New("rediscache: transaction conflict, retry")
+
+// txnRead is the snapshot of a key's value taken the first time a
+// transaction reads it, used by commitTxn to detect conflicting writes
+// from other clients between that read and End.
+type txnRead struct {
+	present bool
+	data    []byte
+}
+
+// txnCache buffers Set/Delete calls in mutates and flushes them from End,
+// atomically when the outermost parent is a Redis-backed Cache from New
+// and one mutation at a time for any other parent. Begin may be called again on a txnCache to stack
+// a nested transaction: the nested transaction's End merges its buffered
+// mutations into the parent instead of touching Redis, so only the
+// outermost End ever commits.
+type txnCache struct {
+	parent   Cache
+	codec    Codec
+	mutates  map[string][]byte
+	ttls     map[string]time.Time
+	watched  map[string]txnRead
+	expireAt time.Time
+}
+
+func (c *txnCache) Get(ctx context.Context, id string, res interface{}) error {
+	raw, err := c.GetRaw(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal(raw, res)
+}
+
+func (c *txnCache) GetRaw(ctx context.Context, id string) ([]byte, error) {
+	if v, ok := c.mutates[id]; ok {
+		if v == nil {
+			return nil, ErrCacheMiss
+		}
+		return v, nil
+	}
+
+	raw, err := c.parent.GetRaw(ctx, id)
+	if err != nil && err != ErrCacheMiss {
+		return nil, err
+	}
+	// Record the value as of this first read, not the value at commit
+	// time, so commitTxn can detect anything that changed in between.
+	if _, ok := c.watched[id]; !ok {
+		c.watched[id] = txnRead{present: err == nil, data: raw}
+	}
+	return raw, err
+}
+
+func (c *txnCache) Set(ctx context.Context, id string, res interface{}) error {
+	b, err := c.codec.Marshal(res)
+	if err != nil {
+		return err
+	}
+	c.mutates[id] = b
+	delete(c.ttls, id)
+	return nil
+}
+
+func (c *txnCache) SetWithTTL(ctx context.Context, id string, res interface{}, ttl time.Duration) error {
+	b, err := c.codec.Marshal(res)
+	if err != nil {
+		return err
+	}
+	c.mutates[id] = b
+	c.ttls[id] = time.Now().Add(ttl)
+	return nil
+}
+
+func (c *txnCache) Expire(ctx context.Context, id string, at time.Time) error {
+	return c.parent.Expire(ctx, id, at)
+}
+
+func (c *txnCache) SetRaw(ctx context.Context, id string, data []byte) error {
+	return c.parent.SetRaw(ctx, id, data)
+}
+
+func (c *txnCache) SetRawWithTTL(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	return c.parent.SetRawWithTTL(ctx, id, data, ttl)
+}
+
+func (c *txnCache) Delete(ctx context.Context, id string) error {
+	c.mutates[id] = nil
+	delete(c.ttls, id)
+	return nil
+}
+
+func (c *txnCache) GetMulti(ctx context.Context, ids []string, out map[string]interface{}, factory func(id string) interface{}) error {
+	for _, id := range ids {
+		res := factory(id)
+		if err := c.Get(ctx, id, res); err != nil {
+			if err == ErrCacheMiss {
+				continue
+			}
+			return err
+		}
+		out[id] = res
+	}
+	return nil
+}
+
+func (c *txnCache) SetMulti(ctx context.Context, items map[string]interface{}) error {
+	for id, res := range items {
+		if err := c.Set(ctx, id, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *txnCache) DeleteMulti(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if err := c.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Begin stacks a nested transaction on top of c. The nested transaction
+// shares c's codec and expiry but buffers its own mutations, which are
+// merged into c when the nested transaction's End is called.
+func (c *txnCache) Begin(max time.Duration) Cache {
+	return &txnCache{
+		expireAt: time.Now().Add(max),
+		parent:   c,
+		codec:    c.codec,
+		mutates:  make(map[string][]byte),
+		ttls:     make(map[string]time.Time),
+		watched:  make(map[string]txnRead),
+	}
+}
+
+func (c *txnCache) End(ctx context.Context) error {
+	switch parent := c.parent.(type) {
+	case *txnCache:
+		for id, v := range c.mutates {
+			parent.mutates[id] = v
+		}
+		for id, at := range c.ttls {
+			parent.ttls[id] = at
+		}
+		for id, r := range c.watched {
+			if _, ok := parent.watched[id]; !ok {
+				parent.watched[id] = r
+			}
+		}
+		return nil
+	case *rootCache:
+		return parent.commitTxn(ctx, c.mutates, c.watched, c.ttls, c.expireAt)
+	default:
+		// Adapter backends (e.g. the memory/null caches) don't support
+		// MULTI/EXEC, so fall back to applying each mutation in turn.
+		for id, v := range c.mutates {
+			var err error
+			if v == nil {
+				err = c.parent.Delete(ctx, id)
+			} else {
+				err = c.parent.SetRaw(ctx, id, v)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
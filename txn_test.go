@@ -0,0 +1,114 @@
+package rediscache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTxnCacheCommitsOnEnd(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+
+	txn := c.Begin(time.Minute)
+	if err := txn.Set(ctx, "a", "1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// The write must not be visible on the parent until End is called.
+	var got string
+	if err := c.Get(ctx, "a", &got); err != ErrCacheMiss {
+		t.Fatalf("Get before End: got err %v, want ErrCacheMiss", err)
+	}
+
+	if err := txn.End(ctx); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get after End: %v", err)
+	}
+	if got != "1" {
+		t.Fatalf("got %q, want %q", got, "1")
+	}
+}
+
+func TestTxnCacheDeleteBuffered(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	txn := c.Begin(time.Minute)
+	if err := txn.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	var got string
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get before End: %v (delete should not be visible yet)", err)
+	}
+
+	if err := txn.End(ctx); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+	if err := c.Get(ctx, "a", &got); err != ErrCacheMiss {
+		t.Fatalf("Get after End: got err %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestTxnCacheNestedMergesOnOuterEndOnly(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+
+	outer := c.Begin(time.Minute)
+	inner := outer.Begin(time.Minute)
+	if err := inner.Set(ctx, "a", "1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if err := inner.End(ctx); err != nil {
+		t.Fatalf("inner End: %v", err)
+	}
+
+	// Merged into outer's buffer, but outer hasn't committed yet.
+	var got string
+	if err := c.Get(ctx, "a", &got); err != ErrCacheMiss {
+		t.Fatalf("Get after inner End: got err %v, want ErrCacheMiss (outer not committed)", err)
+	}
+	if err := outer.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("outer.Get: %v (should see the merged write)", err)
+	}
+
+	if err := outer.End(ctx); err != nil {
+		t.Fatalf("outer End: %v", err)
+	}
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get after outer End: %v", err)
+	}
+	if got != "1" {
+		t.Fatalf("got %q, want %q", got, "1")
+	}
+}
+
+func TestTxnCacheGetReflectsOwnUncommittedWrites(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+	if err := c.Set(ctx, "a", "old"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	txn := c.Begin(time.Minute)
+	if err := txn.Set(ctx, "a", "new"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	var got string
+	if err := txn.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "new" {
+		t.Fatalf("got %q, want %q", got, "new")
+	}
+}
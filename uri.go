@@ -0,0 +1,51 @@
+package rediscache
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewFromURI builds a Cache directly from a Redis connection URI, e.g.
+// "redis://host:6379/0?prefix=app&ttl=1h". Supported query parameters:
+//
+//	prefix - key prefix, equivalent to the prefix argument to New
+//	ttl    - default TTL for Set/SetRaw, parsed with time.ParseDuration
+//
+// Both are consumed before the URI reaches redis.ParseURL, which
+// otherwise rejects query parameters it doesn't recognize. Any Options
+// passed in are applied after the URI is parsed, so they can override
+// what the URI specifies.
+func NewFromURI(uri string, opts ...Option) (Cache, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("rediscache: parsing URI: %w", err)
+	}
+	q := u.Query()
+
+	prefix := q.Get("prefix")
+	ttl := q.Get("ttl")
+	q.Del("prefix")
+	q.Del("ttl")
+	u.RawQuery = q.Encode()
+
+	redisOpts, err := redis.ParseURL(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("rediscache: parsing URI: %w", err)
+	}
+
+	uriOpts := make([]Option, 0, len(opts)+1)
+	if ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("rediscache: parsing ttl query param: %w", err)
+		}
+		uriOpts = append(uriOpts, WithDefaultTTL(d))
+	}
+	uriOpts = append(uriOpts, opts...)
+
+	client := redis.NewClient(redisOpts)
+	return New(client, prefix, uriOpts...), nil
+}